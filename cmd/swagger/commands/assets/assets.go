@@ -0,0 +1,17 @@
+// Package assets embeds pinned copies of the Swagger-UI and Redoc static assets so that
+// `swagger serve --offline` can render the docs UI without reaching out to a CDN.
+package assets
+
+import (
+	"embed"
+	"net/http"
+)
+
+//go:embed swagger-ui redoc
+var files embed.FS
+
+// Handler serves the embedded UI assets, stripping prefix from the request path before
+// looking the file up in the embedded filesystem.
+func Handler(prefix string) http.Handler {
+	return http.StripPrefix(prefix, http.FileServer(http.FS(files)))
+}
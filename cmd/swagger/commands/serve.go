@@ -1,15 +1,23 @@
 package commands
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	htmltemplate "html/template"
 	"log"
 	"net"
 	"net/http"
+	"os"
+	"os/signal"
 	"path"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/go-openapi/loads"
 	"github.com/go-openapi/runtime/middleware"
@@ -17,24 +25,58 @@ import (
 	"github.com/go-openapi/swag"
 	"github.com/gorilla/handlers"
 	"github.com/toqueteos/webbrowser"
+
+	"github.com/songxiaokui/go-swagger/cmd/swagger/commands/assets"
 )
 
 // 国内云端存储的swagger-ui的样式文件网络下载地址前缀
 const DefaultSwaggerCloudStaticFileUrLPrefix string = "https://unpkg.com/swagger-ui-dist"
 const DefaultRedocCloudStaticFileUrLPrefix string = "https://cdn.jsdelivr.net/npm/redoc/bundles"
 
+// specFileGlobs are the file patterns looked up when a directory is passed to the serve command
+var specFileGlobs = []string{"*.swagger.json", "*.yaml", "*.yml"}
+
 // ServeCmd to serve a swagger spec with docs ui
 type ServeCmd struct {
 	BasePath  string `long:"base-path" description:"the base path to serve the spec and UI at"`
-	Flavor    string `short:"F" long:"flavor" description:"the flavor of docs, can be swagger or redoc" default:"redoc" choice:"redoc" choice:"swagger"`
+	Flavor    string `short:"F" long:"flavor" description:"the flavor of docs, can be swagger, redoc, rapidoc or elements" default:"redoc" choice:"redoc" choice:"swagger" choice:"rapidoc" choice:"elements"`
 	DocURL    string `long:"doc-url" description:"override the url which takes a url query param to render the doc ui"`
 	NoOpen    bool   `long:"no-open" description:"when present won't open the the browser to show the url"`
 	NoUI      bool   `long:"no-ui" description:"when present, only the swagger spec will be served"`
 	Flatten   bool   `long:"flatten" description:"when present, flatten the swagger spec before serving it"`
 	Port      int    `long:"port" short:"p" description:"the port to serve this site" env:"PORT"`
 	Host      string `long:"host" description:"the interface to serve this site, defaults to 0.0.0.0" default:"0.0.0.0" env:"HOST"`
-	Path      string `long:"path" description:"the uri path at which the docs will be served" default:"docs"`
+	Path      string `long:"path" description:"the uri path under which each spec's docs will be mounted, one subdirectory per spec" default:"docs"`
 	SourceUrL string `long:"source_url" description:"specifies the path to the swaager-ui render style file download url prefix" short:"S"`
+
+	TLSCertificate    string        `long:"tls-cert" description:"the certificate file to use for TLS"`
+	TLSCertificateKey string        `long:"tls-key" description:"the private key file to use for TLS"`
+	RedirectToTLS     bool          `long:"redirect-http" description:"when TLS is enabled, also run a plain HTTP server on a sibling port that redirects to the HTTPS site"`
+	RedirectPort      int           `long:"redirect-port" description:"the port for the plain HTTP redirect server, defaults to the served port + 1" env:"REDIRECT_PORT"`
+	RootPath          string        `long:"root-path" description:"the path this site is mounted at behind a reverse proxy, stripped from incoming requests and prepended back onto served spec/UI urls"`
+	ShutdownGrace     time.Duration `long:"shutdown-grace" description:"grace period to let in-flight requests complete when shutting down" default:"15s"`
+
+	Offline bool `long:"offline" description:"serve a reduced-functionality swagger-ui/redoc UI embedded in the binary instead of fetching the full UI from a CDN; not supported with --flavor rapidoc/elements"`
+
+	UITemplate string   `long:"ui-template" description:"path to a custom html/template file that replaces the default UI shell"`
+	UIVars     []string `long:"ui-var" description:"key=value pair exposed to the custom UI template, repeatable"`
+
+	BasicAuth   string   `long:"basic-auth" description:"require HTTP basic auth for a single user, as user:pass"`
+	AuthFile    string   `long:"auth-file" description:"path to an htpasswd file of users allowed to authenticate via HTTP basic auth"`
+	BearerToken string   `long:"bearer-token" description:"require this bearer token in the Authorization header"`
+	RateLimit   int      `long:"rate-limit" description:"maximum requests per minute per remote address, 0 disables rate limiting"`
+	CORSOrigin  []string `long:"cors-origin" description:"allowed CORS origin, repeatable; when omitted all origins are allowed"`
+	AccessLog   bool     `long:"access-log" description:"emit a structured access log line for every request"`
+}
+
+// docMount holds the handler and bookkeeping for a single spec mounted by the serve command
+type docMount struct {
+	Name     string
+	Source   string
+	DocPath  string
+	External string
+	SpecURL  string
+	Handler  http.Handler
 }
 
 // Execute the serve command
@@ -43,32 +85,29 @@ func (s *ServeCmd) Execute(args []string) error {
 		return errors.New("specify the spec to serve as argument to the serve command")
 	}
 
-	specDoc, err := loads.Spec(args[0])
+	specPaths, err := resolveSpecPaths(args)
 	if err != nil {
 		return err
 	}
-
-	if s.Flatten {
-		specDoc, err = specDoc.Expanded(&spec.ExpandOptions{
-			SkipSchemas:         false,
-			ContinueOnError:     true,
-			AbsoluteCircularRef: true,
-		})
-
-		if err != nil {
-			return err
-		}
+	if len(specPaths) == 0 {
+		return errors.New("no spec files found to serve")
 	}
-
-	b, err := json.MarshalIndent(specDoc.Spec(), "", "  ")
-	if err != nil {
-		return err
+	if s.Offline && (s.Flavor == "rapidoc" || s.Flavor == "elements") {
+		return fmt.Errorf("--offline has no embedded assets for --flavor %s, only redoc and swagger are supported offline", s.Flavor)
+	}
+	if s.Offline {
+		log.Println("warning: --offline serves a reduced-functionality UI (no try-it-out, plain operation list); it is not the full CDN-backed swagger-ui/redoc experience")
 	}
 
 	basePath := s.BasePath
 	if basePath == "" {
 		basePath = "/"
 	}
+	rootPath := s.RootPath
+	if rootPath != "" {
+		rootPath = path.Join("/", rootPath)
+	}
+	useTLS := s.TLSCertificate != "" && s.TLSCertificateKey != ""
 
 	listener, err := net.Listen("tcp4", net.JoinHostPort(s.Host, strconv.Itoa(s.Port)))
 	if err != nil {
@@ -81,36 +120,302 @@ func (s *ServeCmd) Execute(args []string) error {
 	if sh == "0.0.0.0" {
 		sh = "localhost"
 	}
-
-	visit := s.DocURL
-	handler := http.NotFoundHandler()
+	scheme := "http"
+	if useTLS {
+		scheme = "https"
+	}
 
 	// redoc格式使用
 	var reDocUrlPrefix string
 	// swagger-ui样式使用
 	var swaggerUiUrlPrefix string
-	if s.SourceUrL != "" {
+	// rapidoc样式使用
+	var rapiDocUrlPrefix string
+	// stoplight elements样式使用
+	var elementsUrlPrefix string
+	assetsPath := path.Join(basePath, "assets")
+	switch {
+	case s.Offline:
+		// rapidoc/elements have no embedded assets; Execute rejects --offline for those
+		// flavors above, so these two prefixes are never actually used in offline mode.
+		assetsExternal := path.Join(rootPath, assetsPath)
+		reDocUrlPrefix = path.Join(assetsExternal, "redoc")
+		swaggerUiUrlPrefix = path.Join(assetsExternal, "swagger-ui")
+		rapiDocUrlPrefix = DefaultRapiDocCloudStaticFileUrLPrefix
+		elementsUrlPrefix = DefaultElementsCloudStaticFileUrLPrefix
+	case s.SourceUrL != "":
 		reDocUrlPrefix = s.SourceUrL
 		swaggerUiUrlPrefix = s.SourceUrL
-	} else {
+		rapiDocUrlPrefix = s.SourceUrL
+		elementsUrlPrefix = s.SourceUrL
+	default:
 		reDocUrlPrefix = DefaultRedocCloudStaticFileUrLPrefix
 		swaggerUiUrlPrefix = DefaultSwaggerCloudStaticFileUrLPrefix
+		rapiDocUrlPrefix = DefaultRapiDocCloudStaticFileUrLPrefix
+		elementsUrlPrefix = DefaultElementsCloudStaticFileUrLPrefix
 	}
 
-	if !s.NoUI {
-		if s.Flavor == "redoc" {
-			handler = middleware.Redoc(middleware.RedocOpts{
-				BasePath: basePath,
-				SpecURL:  path.Join(basePath, "swagger.json"),
-				Path:     s.Path,
-				RedocURL: strings.Join([]string{reDocUrlPrefix, "redoc.standalone.js"}, "/"),
+	var uiTemplate *htmltemplate.Template
+	if s.UITemplate != "" {
+		uiTemplate, err = htmltemplate.ParseFiles(s.UITemplate)
+		if err != nil {
+			return err
+		}
+	}
+	uiVars, err := parseUIVars(s.UIVars)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	if s.Offline {
+		assetsHandler := assets.Handler(assetsPath)
+		mux.Handle(assetsPath+"/", assetsHandler)
+	}
+	mounts := make([]docMount, 0, len(specPaths))
+	for _, specPath := range specPaths {
+		mount, err := s.buildMount(specPath, basePath, rootPath, reDocUrlPrefix, swaggerUiUrlPrefix, rapiDocUrlPrefix, elementsUrlPrefix, uiTemplate, uiVars)
+		if err != nil {
+			return err
+		}
+		mounts = append(mounts, mount)
+	}
+	if err := registerMounts(mux, mounts); err != nil {
+		return err
+	}
+
+	visit := s.DocURL
+	if visit == "" {
+		if len(mounts) == 1 {
+			visit = fmt.Sprintf("%s://%s:%d%s", scheme, sh, sp, mounts[0].External)
+		} else {
+			visit = fmt.Sprintf("%s://%s:%d%s", scheme, sh, sp, path.Join(rootPath, basePath))
+		}
+	}
+
+	if len(mounts) > 1 {
+		mux.HandleFunc(basePath, serveIndex(mounts))
+	}
+
+	authUsers, err := s.basicAuthUsers()
+	if err != nil {
+		return err
+	}
+
+	var handler http.Handler = mux
+	if rootPath != "" {
+		handler = stripRootPath(rootPath, handler)
+	}
+	if len(authUsers) > 0 {
+		handler = basicAuthMiddleware(authUsers, handler)
+	}
+	if s.BearerToken != "" {
+		handler = bearerAuthMiddleware(s.BearerToken, handler)
+	}
+	if s.RateLimit > 0 {
+		handler = rateLimitMiddleware(s.RateLimit, handler)
+	}
+	if s.AccessLog {
+		handler = accessLogMiddleware(handler)
+	}
+	corsOpts := []handlers.CORSOption{}
+	if len(s.CORSOrigin) > 0 {
+		corsOpts = append(corsOpts, handlers.AllowedOrigins(s.CORSOrigin))
+	}
+	handler = handlers.CORS(corsOpts...)(handler)
+
+	docServer := new(http.Server)
+	docServer.SetKeepAlivesEnabled(true)
+	docServer.Handler = handler
+
+	errFuture := make(chan error, 1)
+	go func() {
+		if useTLS {
+			errFuture <- docServer.ServeTLS(listener, s.TLSCertificate, s.TLSCertificateKey)
+		} else {
+			errFuture <- docServer.Serve(listener)
+		}
+	}()
+
+	var redirectServer *http.Server
+	if useTLS && s.RedirectToTLS {
+		redirectPort := s.RedirectPort
+		if redirectPort == 0 {
+			redirectPort = sp + 1
+		}
+		redirectListener, err := net.Listen("tcp4", net.JoinHostPort(s.Host, strconv.Itoa(redirectPort)))
+		if err != nil {
+			return err
+		}
+		redirectServer = &http.Server{Handler: redirectToTLS(sh, sp)}
+		go func() {
+			errFuture <- redirectServer.Serve(redirectListener)
+		}()
+	}
+
+	if !s.NoOpen && !s.NoUI {
+		err := webbrowser.Open(visit)
+		if err != nil {
+			return err
+		}
+	}
+	log.Println("serving docs at", visit)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case err := <-errFuture:
+		return err
+	case <-sigCh:
+		log.Println("shutting down, waiting up to", s.ShutdownGrace, "for in-flight requests")
+		ctx, cancel := context.WithTimeout(context.Background(), s.ShutdownGrace)
+		defer cancel()
+		if redirectServer != nil {
+			_ = redirectServer.Shutdown(ctx)
+		}
+		return docServer.Shutdown(ctx)
+	}
+}
+
+// stripRootPath removes root from the start of incoming request paths before dispatch,
+// so the mux can match routes registered without the reverse-proxy prefix.
+func stripRootPath(root string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == root:
+			r.URL.Path = "/"
+		case strings.HasPrefix(r.URL.Path, root+"/"):
+			r.URL.Path = strings.TrimPrefix(r.URL.Path, root)
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// redirectToTLS sends every request to the https equivalent of the same host and path
+func redirectToTLS(host string, tlsPort int) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		target := fmt.Sprintf("https://%s:%d%s", host, tlsPort, r.URL.RequestURI())
+		http.Redirect(rw, r, target, http.StatusMovedPermanently)
+	})
+}
+
+// uiTemplateContext is exposed to a custom --ui-template
+type uiTemplateContext struct {
+	SpecURL     string
+	BasePath    string
+	Title       string
+	AssetPrefix string
+	Vars        map[string]string
+}
+
+// uiTemplateHandler serves a custom UI shell rendered from tmpl at docPath, falling through
+// to next for every other request (typically the spec JSON).
+func uiTemplateHandler(tmpl *htmltemplate.Template, docPath string, ctx uiTemplateContext, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if path.Clean(r.URL.Path) == docPath {
+			rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+			if err := tmpl.Execute(rw, ctx); err != nil {
+				http.Error(rw, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// parseUIVars turns repeated "key=value" --ui-var flags into a map for the UI template context
+func parseUIVars(raw []string) (map[string]string, error) {
+	vars := make(map[string]string, len(raw))
+	for _, kv := range raw {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid --ui-var %q, expected key=value", kv)
+		}
+		vars[parts[0]] = parts[1]
+	}
+	return vars, nil
+}
+
+// buildMount loads a single spec and wires up its UI + spec handlers under their own doc path.
+// basePath is used to match incoming (already root-path-stripped) requests, while rootPath is
+// prepended back onto any url embedded in the served UI/index pages.
+func (s *ServeCmd) buildMount(specPath, basePath, rootPath, reDocUrlPrefix, swaggerUiUrlPrefix, rapiDocUrlPrefix, elementsUrlPrefix string, uiTemplate *htmltemplate.Template, uiVars map[string]string) (docMount, error) {
+	specDoc, err := loads.Spec(specPath)
+	if err != nil {
+		return docMount{}, err
+	}
+
+	if s.Flatten {
+		specDoc, err = specDoc.Expanded(&spec.ExpandOptions{
+			SkipSchemas:         false,
+			ContinueOnError:     true,
+			AbsoluteCircularRef: true,
+		})
+		if err != nil {
+			return docMount{}, err
+		}
+	}
+
+	b, err := json.MarshalIndent(specDoc.Spec(), "", "  ")
+	if err != nil {
+		return docMount{}, err
+	}
+
+	name := specName(specPath)
+	// mountPath is the part of docPath below basePath. It is passed as the vendored
+	// middleware's "Path" option (combined with "BasePath": basePath) so that the UI's
+	// internal route match lands on docPath, instead of letting EnsureDefaults() fold
+	// in its own default Path ("docs") on top of an already-complete docPath.
+	mountPath := path.Join(s.Path, name)
+	docPath := path.Join(basePath, mountPath)
+	externalDocPath := path.Join(rootPath, docPath)
+	specURL := path.Join(externalDocPath, "swagger.json")
+
+	handler := http.NotFoundHandler()
+	if !s.NoUI && uiTemplate != nil {
+		title := name
+		if info := specDoc.Spec().Info; info != nil && info.Title != "" {
+			title = info.Title
+		}
+		var assetPrefix string
+		switch s.Flavor {
+		case "swagger":
+			assetPrefix = swaggerUiUrlPrefix
+		case "rapidoc":
+			assetPrefix = rapiDocUrlPrefix
+		case "elements":
+			assetPrefix = elementsUrlPrefix
+		default:
+			assetPrefix = reDocUrlPrefix
+		}
+		handler = uiTemplateHandler(uiTemplate, docPath, uiTemplateContext{
+			SpecURL:     specURL,
+			BasePath:    docPath,
+			Title:       title,
+			AssetPrefix: assetPrefix,
+			Vars:        uiVars,
+		}, handler)
+	} else if !s.NoUI {
+		switch s.Flavor {
+		case "rapidoc":
+			handler = RapiDoc(RapiDocOpts{
+				BasePath:   docPath,
+				SpecURL:    specURL,
+				RapiDocURL: strings.Join([]string{rapiDocUrlPrefix, "dist", "rapidoc-min.js"}, "/"),
+			}, handler)
+		case "elements":
+			handler = Elements(ElementsOpts{
+				BasePath:          docPath,
+				SpecURL:           specURL,
+				ElementsURL:       strings.Join([]string{elementsUrlPrefix, "web-components.min.js"}, "/"),
+				ElementsStylesURL: strings.Join([]string{elementsUrlPrefix, "styles.min.css"}, "/"),
 			}, handler)
-			visit = fmt.Sprintf("http://%s:%d%s", sh, sp, path.Join(basePath, "docs"))
-		} else if visit != "" || s.Flavor == "swagger" {
+		case "swagger":
 			handler = middleware.SwaggerUI(middleware.SwaggerUIOpts{
 				BasePath: basePath,
-				SpecURL:  path.Join(basePath, "swagger.json"),
-				Path:     s.Path,
+				Path:     mountPath,
+				SpecURL:  specURL,
 				// Update swagger-ui default config
 				SwaggerURL:       strings.Join([]string{swaggerUiUrlPrefix, "swagger-ui-bundle.js"}, "/"),
 				SwaggerPresetURL: strings.Join([]string{swaggerUiUrlPrefix, "swagger-ui-standalone-preset.js"}, "/"),
@@ -118,26 +423,138 @@ func (s *ServeCmd) Execute(args []string) error {
 				Favicon16:        strings.Join([]string{swaggerUiUrlPrefix, "favicon-16x16.png"}, "/"),
 				Favicon32:        strings.Join([]string{swaggerUiUrlPrefix, "favicon-32x32.png"}, "/"),
 			}, handler)
-			visit = fmt.Sprintf("http://%s:%d%s", sh, sp, path.Join(basePath, s.Path))
+		default:
+			handler = middleware.Redoc(middleware.RedocOpts{
+				BasePath: basePath,
+				Path:     mountPath,
+				SpecURL:  specURL,
+				RedocURL: strings.Join([]string{reDocUrlPrefix, "redoc.standalone.js"}, "/"),
+			}, handler)
 		}
 	}
 
-	handler = handlers.CORS()(middleware.Spec(basePath, b, handler))
-	errFuture := make(chan error)
-	go func() {
-		docServer := new(http.Server)
-		docServer.SetKeepAlivesEnabled(true)
-		docServer.Handler = handler
+	handler = middleware.Spec(docPath, b, handler)
 
-		errFuture <- docServer.Serve(listener)
-	}()
+	return docMount{
+		Name:     name,
+		Source:   specPath,
+		DocPath:  docPath,
+		External: externalDocPath,
+		SpecURL:  specURL,
+		Handler:  handler,
+	}, nil
+}
 
-	if !s.NoOpen && !s.NoUI {
-		err := webbrowser.Open(visit)
+// basicAuthUsers merges the single --basic-auth user with any users loaded from --auth-file
+func (s *ServeCmd) basicAuthUsers() (map[string]string, error) {
+	users := make(map[string]string)
+	if s.BasicAuth != "" {
+		parts := strings.SplitN(s.BasicAuth, ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid --basic-auth %q, expected user:pass", s.BasicAuth)
+		}
+		users[parts[0]] = parts[1]
+	}
+	if s.AuthFile != "" {
+		fileUsers, err := loadHtpasswd(s.AuthFile)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		for user, hash := range fileUsers {
+			users[user] = hash
 		}
 	}
-	log.Println("serving docs at", visit)
-	return <-errFuture
+	return users, nil
 }
+
+// registerMounts mounts each doc on mux, rejecting any that collide on the same doc path
+// instead of letting http.ServeMux.Handle panic on the duplicate registration.
+func registerMounts(mux *http.ServeMux, mounts []docMount) error {
+	seen := make(map[string]string, len(mounts))
+	for _, mount := range mounts {
+		if prior, ok := seen[mount.DocPath]; ok {
+			return fmt.Errorf("spec %q and %q both mount at %q, pick distinct file names or use --path to disambiguate", prior, mount.Source, mount.DocPath)
+		}
+		seen[mount.DocPath] = mount.Source
+		mux.Handle(mount.DocPath, mount.Handler)
+		mux.Handle(mount.DocPath+"/", mount.Handler)
+	}
+	return nil
+}
+
+// resolveSpecPaths expands any directory arguments into the spec files they contain
+func resolveSpecPaths(args []string) ([]string, error) {
+	var paths []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			paths = append(paths, arg)
+			continue
+		}
+
+		var matches []string
+		for _, glob := range specFileGlobs {
+			found, err := filepath.Glob(filepath.Join(arg, glob))
+			if err != nil {
+				return nil, err
+			}
+			matches = append(matches, found...)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no spec files found in directory %q", arg)
+		}
+		sort.Strings(matches)
+		paths = append(paths, matches...)
+	}
+	return paths, nil
+}
+
+// specName derives the mount name for a spec file from its base name
+func specName(specPath string) string {
+	base := filepath.Base(specPath)
+	for _, ext := range []string{".swagger.json", ".json", ".yaml", ".yml"} {
+		if strings.HasSuffix(base, ext) {
+			return strings.TrimSuffix(base, ext)
+		}
+	}
+	return base
+}
+
+// serveIndex renders an HTML page linking to every mounted spec
+func serveIndex(mounts []docMount) http.HandlerFunc {
+	var b strings.Builder
+	b.WriteString(indexPageHeader)
+	for _, mount := range mounts {
+		b.WriteString(fmt.Sprintf(indexPageItem, mount.External, mount.Name))
+	}
+	b.WriteString(indexPageFooter)
+	page := []byte(b.String())
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+		rw.WriteHeader(http.StatusOK)
+		_, _ = rw.Write(page)
+	}
+}
+
+const indexPageHeader = `<!DOCTYPE html>
+<html>
+  <head>
+    <title>API documentation</title>
+    <meta charset="utf-8">
+  </head>
+  <body>
+    <h1>API documentation</h1>
+    <ul>
+`
+
+const indexPageItem = `      <li><a href="%s">%s</a></li>
+`
+
+const indexPageFooter = `    </ul>
+  </body>
+</html>
+`
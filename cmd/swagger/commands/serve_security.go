@@ -0,0 +1,148 @@
+package commands
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// loadHtpasswd reads an htpasswd file into a map of user to password hash/plaintext.
+// Both bcrypt ($2y$/$2a$/$2b$) and plaintext entries are supported.
+func loadHtpasswd(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	users := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("malformed htpasswd line: %q", line)
+		}
+		users[parts[0]] = parts[1]
+	}
+	return users, scanner.Err()
+}
+
+// checkBasicAuthPassword compares a plaintext password against a stored htpasswd credential.
+func checkBasicAuthPassword(stored, given string) bool {
+	if strings.HasPrefix(stored, "$2a$") || strings.HasPrefix(stored, "$2b$") || strings.HasPrefix(stored, "$2y$") {
+		return bcrypt.CompareHashAndPassword([]byte(stored), []byte(given)) == nil
+	}
+	return subtle.ConstantTimeCompare([]byte(stored), []byte(given)) == 1
+}
+
+// basicAuthMiddleware requires one of the configured users to authenticate via HTTP basic auth
+func basicAuthMiddleware(users map[string]string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || !checkBasicAuthPassword(users[user], pass) {
+			rw.Header().Set("WWW-Authenticate", `Basic realm="swagger docs"`)
+			http.Error(rw, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// bearerAuthMiddleware requires the configured bearer token on every request
+func bearerAuthMiddleware(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if got == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			rw.Header().Set("WWW-Authenticate", `Bearer realm="swagger docs"`)
+			http.Error(rw, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// rateLimiter enforces a sliding-window limit of requests per minute, keyed by remote address
+type rateLimiter struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	hits   map[string][]time.Time
+}
+
+func newRateLimiter(requestsPerMinute int) *rateLimiter {
+	return &rateLimiter{
+		limit:  requestsPerMinute,
+		window: time.Minute,
+		hits:   make(map[string][]time.Time),
+	}
+}
+
+func (l *rateLimiter) allow(key string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cutoff := now.Add(-l.window)
+	hits := l.hits[key]
+	fresh := hits[:0]
+	for _, t := range hits {
+		if t.After(cutoff) {
+			fresh = append(fresh, t)
+		}
+	}
+	if len(fresh) >= l.limit {
+		l.hits[key] = fresh
+		return false
+	}
+	l.hits[key] = append(fresh, now)
+	return true
+}
+
+// rateLimitMiddleware rejects requests once a remote address exceeds requestsPerMinute
+func rateLimitMiddleware(requestsPerMinute int, next http.Handler) http.Handler {
+	limiter := newRateLimiter(requestsPerMinute)
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		if !limiter.allow(host, time.Now()) {
+			http.Error(rw, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// statusRecorder captures the response status code for access logging
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// accessLogMiddleware logs method, path, remote address, status and duration for every request
+func accessLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: rw, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		log.Printf("access %s %s %s %d %s", r.RemoteAddr, r.Method, r.URL.Path, rec.status, time.Since(start))
+	})
+}
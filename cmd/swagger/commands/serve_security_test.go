@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	l := newRateLimiter(2)
+	now := time.Now()
+
+	if !l.allow("1.2.3.4", now) {
+		t.Fatal("first request should be allowed")
+	}
+	if !l.allow("1.2.3.4", now) {
+		t.Fatal("second request should be allowed")
+	}
+	if l.allow("1.2.3.4", now) {
+		t.Fatal("third request within the window should be rejected")
+	}
+
+	// a different key has its own budget
+	if !l.allow("5.6.7.8", now) {
+		t.Fatal("a different key should not be affected by another key's limit")
+	}
+
+	// once the window elapses, the key is allowed again
+	if !l.allow("1.2.3.4", now.Add(2*time.Minute)) {
+		t.Fatal("request after the window should be allowed")
+	}
+}
+
+// TestRateLimitMiddlewareKeysOnHostNotPort guards against keying the limiter on the raw
+// RemoteAddr (host:port): two requests from the same host on different ports must share
+// the same budget.
+func TestRateLimitMiddlewareKeysOnHostNotPort(t *testing.T) {
+	handler := rateLimitMiddleware(1, http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+
+	req1 := httptest.NewRequest(http.MethodGet, "/", nil)
+	req1.RemoteAddr = "10.0.0.1:50001"
+	rec1 := httptest.NewRecorder()
+	handler.ServeHTTP(rec1, req1)
+	if rec1.Code != http.StatusOK {
+		t.Fatalf("first request = %d, want 200", rec1.Code)
+	}
+
+	req2 := httptest.NewRequest(http.MethodGet, "/", nil)
+	req2.RemoteAddr = "10.0.0.1:50002"
+	rec2 := httptest.NewRecorder()
+	handler.ServeHTTP(rec2, req2)
+	if rec2.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request from same host, different port = %d, want 429", rec2.Code)
+	}
+}
+
+func TestLoadHtpasswdAndCheckBasicAuthPassword(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "htpasswd")
+	hashBytes, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := "# a comment\nalice:" + string(hashBytes) + "\nbob:plaintext\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	users, err := loadHtpasswd(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 {
+		t.Fatalf("loadHtpasswd found %d users, want 2: %v", len(users), users)
+	}
+
+	if !checkBasicAuthPassword(users["alice"], "s3cret") {
+		t.Error("bcrypt password should match")
+	}
+	if checkBasicAuthPassword(users["alice"], "wrong") {
+		t.Error("wrong bcrypt password should not match")
+	}
+	if !checkBasicAuthPassword(users["bob"], "plaintext") {
+		t.Error("plaintext password should match")
+	}
+	if checkBasicAuthPassword(users["bob"], "wrong") {
+		t.Error("wrong plaintext password should not match")
+	}
+}
+
+func TestBasicAuthUsersMergesFlagAndFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "htpasswd")
+	if err := os.WriteFile(path, []byte("bob:plaintext\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &ServeCmd{BasicAuth: "alice:s3cret", AuthFile: path}
+	users, err := s.basicAuthUsers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if users["alice"] != "s3cret" || users["bob"] != "plaintext" {
+		t.Fatalf("unexpected merged users: %v", users)
+	}
+}
+
+func TestBasicAuthUsersRejectsMalformedFlag(t *testing.T) {
+	s := &ServeCmd{BasicAuth: "no-colon-here"}
+	if _, err := s.basicAuthUsers(); err == nil {
+		t.Fatal("expected an error for a --basic-auth value without a colon")
+	}
+}
@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testSpecJSON = `{
+  "swagger": "2.0",
+  "info": {"title": "Test API", "version": "1.0.0"},
+  "paths": {}
+}`
+
+func writeTestSpec(t *testing.T, dir, name string) string {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, []byte(testSpecJSON), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// TestBuildMountServesAtAdvertisedPath guards against the docPath/Path double-join
+// regression: the UI handler must actually match at mount.DocPath for every flavor,
+// not one path segment deeper.
+func TestBuildMountServesAtAdvertisedPath(t *testing.T) {
+	dir := t.TempDir()
+	specPath := writeTestSpec(t, dir, "petstore.swagger.json")
+
+	for _, flavor := range []string{"redoc", "swagger", "rapidoc", "elements"} {
+		flavor := flavor
+		t.Run(flavor, func(t *testing.T) {
+			s := &ServeCmd{Flavor: flavor, Path: "docs"}
+			mount, err := s.buildMount(specPath, "/", "", "https://redoc.example/bundles", "https://swagger.example/dist", "https://rapidoc.example", "https://elements.example", nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, mount.DocPath, nil)
+			rec := httptest.NewRecorder()
+			mount.Handler.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("GET %s = %d, want 200 (flavor %s)", mount.DocPath, rec.Code, flavor)
+			}
+		})
+	}
+}
+
+func TestRegisterMountsRejectsDuplicateDocPath(t *testing.T) {
+	mounts := []docMount{
+		{Name: "foo", Source: "v1/foo.yaml", DocPath: "/docs/foo", Handler: http.NotFoundHandler()},
+		{Name: "foo", Source: "v2/foo.yaml", DocPath: "/docs/foo", Handler: http.NotFoundHandler()},
+	}
+
+	err := registerMounts(http.NewServeMux(), mounts)
+	if err == nil {
+		t.Fatal("expected an error for colliding doc paths, got nil")
+	}
+}
+
+func TestRegisterMountsDistinctPaths(t *testing.T) {
+	mounts := []docMount{
+		{Name: "foo", Source: "foo.yaml", DocPath: "/docs/foo", Handler: http.NotFoundHandler()},
+		{Name: "bar", Source: "bar.yaml", DocPath: "/docs/bar", Handler: http.NotFoundHandler()},
+	}
+
+	if err := registerMounts(http.NewServeMux(), mounts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSpecName(t *testing.T) {
+	cases := map[string]string{
+		"petstore.swagger.json": "petstore",
+		"api.yaml":              "api",
+		"api.yml":               "api",
+		"plain.json":            "plain",
+	}
+	for in, want := range cases {
+		if got := specName(in); got != want {
+			t.Errorf("specName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestResolveSpecPathsExpandsDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeTestSpec(t, dir, "a.swagger.json")
+	writeTestSpec(t, dir, "b.yaml")
+
+	paths, err := resolveSpecPaths([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("resolveSpecPaths found %d files, want 2: %v", len(paths), paths)
+	}
+}
+
+func TestStripRootPath(t *testing.T) {
+	cases := []struct {
+		root, in, want string
+	}{
+		{"/api", "/api", "/"},
+		{"/api", "/api/docs", "/docs"},
+		{"/api", "/apikey/x", "/apikey/x"}, // must not strip a non-boundary prefix
+		{"/api", "/other", "/other"},
+	}
+	for _, c := range cases {
+		var got string
+		handler := stripRootPath(c.root, http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			got = r.URL.Path
+		}))
+		req := httptest.NewRequest(http.MethodGet, c.in, nil)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if got != c.want {
+			t.Errorf("stripRootPath(%q) on %q = %q, want %q", c.root, c.in, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package commands
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+)
+
+// 国内云端存储的rapidoc/elements样式文件网络下载地址前缀
+const DefaultRapiDocCloudStaticFileUrLPrefix string = "https://unpkg.com/rapidoc"
+const DefaultElementsCloudStaticFileUrLPrefix string = "https://unpkg.com/@stoplight/elements"
+
+// RapiDocOpts configures the RapiDoc handlers
+type RapiDocOpts struct {
+	// BasePath for the UI, defaults to: /
+	BasePath string
+	// Path combines with BasePath to construct the path to the UI, defaults to: "docs"
+	Path string
+	// SpecURL is the url to find the spec for
+	SpecURL string
+	// Title for the documentation site, default to: API documentation
+	Title string
+	// RapiDocURL points to the js asset that powers the rapi-doc web component
+	RapiDocURL string
+}
+
+func (r *RapiDocOpts) EnsureDefaults() {
+	if r.BasePath == "" {
+		r.BasePath = "/"
+	}
+	if r.SpecURL == "" {
+		r.SpecURL = "/swagger.json"
+	}
+	if r.Title == "" {
+		r.Title = "API documentation"
+	}
+	if r.RapiDocURL == "" {
+		r.RapiDocURL = DefaultRapiDocCloudStaticFileUrLPrefix + "/dist/rapidoc-min.js"
+	}
+}
+
+// RapiDoc creates a middleware that serves a RapiDoc UI for a swagger spec.
+// This allows for altering the spec before starting the http listener.
+func RapiDoc(opts RapiDocOpts, next http.Handler) http.Handler {
+	opts.EnsureDefaults()
+
+	pth := path.Join(opts.BasePath, opts.Path)
+	tmpl := fmt.Sprintf(rapiDocTemplate, opts.Title, opts.RapiDocURL, opts.SpecURL)
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if path.Join(r.URL.Path) == pth {
+			rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+			rw.WriteHeader(http.StatusOK)
+			_, _ = rw.Write([]byte(tmpl))
+			return
+		}
+
+		if next == nil {
+			rw.Header().Set("Content-Type", "text/plain")
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+const rapiDocTemplate = `<!DOCTYPE html>
+<html>
+  <head>
+    <title>%s</title>
+    <meta charset="utf-8">
+    <script type="module" src="%s"></script>
+  </head>
+  <body>
+    <rapi-doc spec-url="%s" render-style="view" show-header="false"></rapi-doc>
+  </body>
+</html>
+`
+
+// ElementsOpts configures the Stoplight Elements handlers
+type ElementsOpts struct {
+	// BasePath for the UI, defaults to: /
+	BasePath string
+	// Path combines with BasePath to construct the path to the UI, defaults to: "docs"
+	Path string
+	// SpecURL is the url to find the spec for
+	SpecURL string
+	// Title for the documentation site, default to: API documentation
+	Title string
+	// ElementsURL points to the js bundle for Stoplight Elements
+	ElementsURL string
+	// ElementsStylesURL points to the css bundle for Stoplight Elements
+	ElementsStylesURL string
+}
+
+func (e *ElementsOpts) EnsureDefaults() {
+	if e.BasePath == "" {
+		e.BasePath = "/"
+	}
+	if e.SpecURL == "" {
+		e.SpecURL = "/swagger.json"
+	}
+	if e.Title == "" {
+		e.Title = "API documentation"
+	}
+	if e.ElementsURL == "" {
+		e.ElementsURL = DefaultElementsCloudStaticFileUrLPrefix + "/web-components.min.js"
+	}
+	if e.ElementsStylesURL == "" {
+		e.ElementsStylesURL = DefaultElementsCloudStaticFileUrLPrefix + "/styles.min.css"
+	}
+}
+
+// Elements creates a middleware that serves a Stoplight Elements UI for a swagger spec.
+// This allows for altering the spec before starting the http listener.
+func Elements(opts ElementsOpts, next http.Handler) http.Handler {
+	opts.EnsureDefaults()
+
+	pth := path.Join(opts.BasePath, opts.Path)
+	tmpl := fmt.Sprintf(elementsTemplate, opts.Title, opts.ElementsStylesURL, opts.ElementsURL, opts.SpecURL)
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if path.Join(r.URL.Path) == pth {
+			rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+			rw.WriteHeader(http.StatusOK)
+			_, _ = rw.Write([]byte(tmpl))
+			return
+		}
+
+		if next == nil {
+			rw.Header().Set("Content-Type", "text/plain")
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+const elementsTemplate = `<!DOCTYPE html>
+<html>
+  <head>
+    <title>%s</title>
+    <meta charset="utf-8">
+    <link rel="stylesheet" href="%s">
+    <script src="%s"></script>
+  </head>
+  <body style="height: 100vh;">
+    <elements-api apiDescriptionUrl="%s" router="hash" layout="sidebar"></elements-api>
+  </body>
+</html>
+`